@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthorizeRequiresMatchingScope(t *testing.T) {
+	a, err := New(Config{
+		Enabled: true,
+		Tokens:  []TokenConfig{{Token: "upload-only", Scopes: []string{ScopeUpload}}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/foo.txt", nil)
+	req.Header.Set("Authorization", "Token upload-only")
+
+	if !a.Authorize(req, ScopeUpload) {
+		t.Error("expected upload-only token to authorize the upload scope")
+	}
+	if a.Authorize(req, ScopeDelete) {
+		t.Error("expected upload-only token to not authorize the delete scope")
+	}
+
+	req.Header.Set("Authorization", "Token wrong-token")
+	if a.Authorize(req, ScopeUpload) {
+		t.Error("expected an unrecognized token to be unauthorized")
+	}
+}
+
+func TestIssueAndVerifyDeleteToken(t *testing.T) {
+	a, err := New(Config{SigningKey: "test-signing-key"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	token := a.IssueDeleteToken("foo.txt")
+	if !a.VerifyDeleteToken("foo.txt", token) {
+		t.Error("expected issued delete token to verify for the same key")
+	}
+	if a.VerifyDeleteToken("bar.txt", token) {
+		t.Error("expected a delete token issued for one key to not verify another")
+	}
+}
+
+func TestSignAndVerifyDownloadURL(t *testing.T) {
+	a, err := New(Config{SigningKey: "test-signing-key", DownloadURLTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	signedURL := a.SignDownloadURL("foo.txt")
+
+	req := httptest.NewRequest(http.MethodGet, signedURL, nil)
+	expires := req.URL.Query().Get("expires")
+	signature := req.URL.Query().Get("signature")
+	if expires == "" || signature == "" {
+		t.Fatalf("signed URL missing expires/signature query params: %s", signedURL)
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		t.Fatalf("parsing expires=%q: %v", expires, err)
+	}
+
+	if !a.VerifyDownloadURL("foo.txt", expiresAt, signature) {
+		t.Error("expected a freshly signed download URL to verify")
+	}
+	if a.VerifyDownloadURL("foo.txt", expiresAt-7200, signature) {
+		t.Error("expected an expired timestamp to fail verification")
+	}
+}
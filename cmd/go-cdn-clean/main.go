@@ -0,0 +1,80 @@
+// Command go-cdn-clean runs a single expiry/quota sweep against a go-cdn
+// installation and exits. It is meant to be invoked from cron or a systemd
+// timer on deployments that would rather not rely on the server's built-in
+// background cleanup goroutine.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NotAShelf/go-cdn/lifecycle"
+	"github.com/NotAShelf/go-cdn/storage"
+)
+
+// config is the subset of the server's Config needed to open the same
+// storage backend and metadata database.
+type config struct {
+	Storage         storage.Config `json:"storage"`
+	KeepFor         string         `json:"keep_for"`
+	StorageSize     int64          `json:"storage_size"`
+	MetadataPath    string         `json:"metadata_path"`
+	CleanupInterval string         `json:"cleanup_interval"`
+}
+
+func main() {
+	configPath := flag.String("config", "config.json", "Path to the configuration file")
+	flag.Parse()
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp:   true,
+		TimestampFormat: "2006-01-02 15:04:05",
+	})
+
+	configFile, err := os.Open(*configPath)
+	if err != nil {
+		logger.Fatalf("Error opening configuration file: %v", err)
+	}
+	defer configFile.Close()
+
+	var cfg config
+	if err := json.NewDecoder(configFile).Decode(&cfg); err != nil {
+		logger.Fatalf("Error decoding configuration file: %v", err)
+	}
+
+	if cfg.MetadataPath == "" {
+		logger.Fatal("metadata_path is not configured; nothing to clean")
+	}
+
+	backend, err := storage.New(cfg.Storage)
+	if err != nil {
+		logger.Fatalf("Error initializing storage backend: %v", err)
+	}
+
+	keepFor, err := time.ParseDuration(cfg.KeepFor)
+	if err != nil && cfg.KeepFor != "" {
+		logger.Fatalf("Error parsing keep_for: %v", err)
+	}
+
+	manager, err := lifecycle.NewManager(cfg.MetadataPath, backend, keepFor, cfg.StorageSize)
+	if err != nil {
+		logger.Fatalf("Error opening lifecycle manager: %v", err)
+	}
+	defer manager.Close()
+
+	deleted, err := manager.Sweep()
+	if err != nil {
+		logger.Fatalf("Error sweeping expired uploads: %v", err)
+	}
+
+	fmt.Printf("swept %d object(s)\n", len(deleted))
+	for _, key := range deleted {
+		logger.Infof("Deleted expired/evicted object: %s", key)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/NotAShelf/go-cdn/dedup"
+)
+
+// storeContentAddressed streams r through a SHA-256 hasher, stores it under
+// its digest key (deduplicating identical uploads), and records an alias
+// from filename to that key. It returns the digest key the content now
+// lives under and whether the upload matched an object already on disk
+// (and therefore didn't need scanning again, see CDNHandler.scanUpload).
+func (c *CDNHandler) storeContentAddressed(ctx context.Context, r io.Reader, filename string) (key string, duplicate bool, err error) {
+	spool, err := os.CreateTemp("", "go-cdn-upload-*")
+	if err != nil {
+		return "", false, fmt.Errorf("content-addressing: creating spool file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(spool, io.TeeReader(r, hasher))
+	if err != nil {
+		return "", false, fmt.Errorf("content-addressing: hashing upload: %w", err)
+	}
+	digest := fmt.Sprintf("%x", hasher.Sum(nil))
+	key = dedup.Key(digest)
+
+	if _, err := c.Storage.Stat(ctx, key); err == nil {
+		c.Logger.Infof("Duplicate upload %s matches existing object %s, skipping write", filename, key)
+		duplicate = true
+	} else {
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return "", false, fmt.Errorf("content-addressing: rewinding spool file: %w", err)
+		}
+		if err := c.Storage.Put(ctx, key, spool, size); err != nil {
+			return "", false, fmt.Errorf("content-addressing: storing object: %w", err)
+		}
+	}
+
+	if err := c.Dedup.Put(filename, key); err != nil {
+		return "", false, fmt.Errorf("content-addressing: recording alias: %w", err)
+	}
+
+	return key, duplicate, nil
+}
+
+// resolveContentAddressedKey maps a request path to the storage key that
+// actually holds its content: digest-form paths ("ab/cdef...") are used
+// as-is, everything else is looked up as a filename alias.
+func (c *CDNHandler) resolveContentAddressedKey(key string) (string, error) {
+	if dedup.IsDigestKey(key) {
+		return key, nil
+	}
+	return c.Dedup.Resolve(key)
+}
+
+// setContentAddressedHeaders sets the ETag for a digest-backed object and
+// reports whether the request's If-None-Match already matches it.
+func setContentAddressedHeaders(w http.ResponseWriter, r *http.Request, key string) bool {
+	digest := dedup.DigestFromKey(key)
+	if digest == "" {
+		return false
+	}
+
+	etag := dedup.ETag(digest)
+	w.Header().Set("ETag", etag)
+	return r.Header.Get("If-None-Match") == etag
+}
@@ -0,0 +1,154 @@
+// Package dedup implements content-addressed storage: uploads are keyed by
+// their SHA-256 digest rather than their original filename, so identical
+// uploads share a single stored object (the SeaweedFS needle/fid model
+// applied to go-cdn). A small alias store maps human-friendly filenames
+// back to the digest key that holds their content.
+package dedup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var aliasBucket = []byte("aliases")
+
+// Key returns the storage key for a SHA-256 digest, splitting off the first
+// byte as a directory component (e.g. "ab/cdef0123...") so a single
+// directory never holds every object.
+func Key(digest string) string {
+	if len(digest) < 2 {
+		return digest
+	}
+	return digest[:2] + "/" + digest[2:]
+}
+
+// IsDigestKey reports whether key looks like a digest key produced by Key,
+// as opposed to a human-friendly alias.
+func IsDigestKey(key string) bool {
+	return len(key) == 65 && key[2] == '/'
+}
+
+// alias records which digest key holds the content for a given
+// human-friendly filename.
+type alias struct {
+	DigestKey string `json:"digest_key"`
+}
+
+// AliasStore persists filename-to-digest-key mappings in an embedded bbolt
+// database.
+type AliasStore struct {
+	db *bolt.DB
+}
+
+// NewAliasStore opens (or creates) the bbolt database at path.
+func NewAliasStore(path string) (*AliasStore, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dedup: opening alias db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(aliasBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("dedup: initializing alias bucket: %w", err)
+	}
+
+	return &AliasStore{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *AliasStore) Close() error {
+	return s.db.Close()
+}
+
+// Put records that filename's content lives under digestKey.
+func (s *AliasStore) Put(filename, digestKey string) error {
+	data, err := json.Marshal(alias{DigestKey: digestKey})
+	if err != nil {
+		return fmt.Errorf("dedup: marshaling alias: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(aliasBucket).Put([]byte(filename), data)
+	})
+	if err != nil {
+		return fmt.Errorf("dedup: recording alias: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the alias recorded for filename, without touching the
+// digest-keyed object it points to.
+func (s *AliasStore) Delete(filename string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(aliasBucket).Delete([]byte(filename))
+	})
+	if err != nil {
+		return fmt.Errorf("dedup: deleting alias: %w", err)
+	}
+	return nil
+}
+
+// RefCount returns the number of aliases currently pointing at digestKey, so
+// callers can tell whether a digest-keyed object is still referenced by
+// other filenames before deleting it.
+func (s *AliasStore) RefCount(digestKey string) (int, error) {
+	var count int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(aliasBucket).ForEach(func(_, v []byte) error {
+			var a alias
+			if err := json.Unmarshal(v, &a); err != nil {
+				return err
+			}
+			if a.DigestKey == digestKey {
+				count++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("dedup: counting aliases: %w", err)
+	}
+	return count, nil
+}
+
+// Resolve returns the digest key recorded for filename.
+func (s *AliasStore) Resolve(filename string) (string, error) {
+	var a alias
+	found := false
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(aliasBucket).Get([]byte(filename))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &a)
+	})
+	if err != nil {
+		return "", fmt.Errorf("dedup: reading alias: %w", err)
+	}
+	if !found {
+		return "", fmt.Errorf("dedup: no alias recorded for %q", filename)
+	}
+	return a.DigestKey, nil
+}
+
+// ETag formats a digest as the "sha256-<hex>" value go-cdn uses for the
+// ETag response header.
+func ETag(digest string) string {
+	return fmt.Sprintf("sha256-%s", digest)
+}
+
+// DigestFromKey recovers the hex digest from a key produced by Key.
+func DigestFromKey(key string) string {
+	if !IsDigestKey(key) {
+		return ""
+	}
+	return key[:2] + key[3:]
+}
@@ -0,0 +1,55 @@
+package dedup
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAliasStoreResolveDeleteRefCount(t *testing.T) {
+	db, err := NewAliasStore(filepath.Join(t.TempDir(), "aliases.db"))
+	if err != nil {
+		t.Fatalf("NewAliasStore: %v", err)
+	}
+	defer db.Close()
+
+	const digestKey = "ab/cdef0123"
+	if err := db.Put("a.txt", digestKey); err != nil {
+		t.Fatalf("Put a.txt: %v", err)
+	}
+	if err := db.Put("b.txt", digestKey); err != nil {
+		t.Fatalf("Put b.txt: %v", err)
+	}
+
+	resolved, err := db.Resolve("a.txt")
+	if err != nil || resolved != digestKey {
+		t.Fatalf("Resolve(a.txt) = %q, %v, want %q, nil", resolved, err, digestKey)
+	}
+
+	if refs, err := db.RefCount(digestKey); err != nil || refs != 2 {
+		t.Fatalf("RefCount = %d, %v, want 2, nil", refs, err)
+	}
+
+	if err := db.Delete("a.txt"); err != nil {
+		t.Fatalf("Delete a.txt: %v", err)
+	}
+	if _, err := db.Resolve("a.txt"); err == nil {
+		t.Fatal("expected Resolve(a.txt) to fail after Delete")
+	}
+	if refs, err := db.RefCount(digestKey); err != nil || refs != 1 {
+		t.Fatalf("RefCount after delete = %d, %v, want 1, nil", refs, err)
+	}
+}
+
+func TestKeyAndIsDigestKey(t *testing.T) {
+	digest := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	key := Key(digest)
+	if !IsDigestKey(key) {
+		t.Fatalf("IsDigestKey(%q) = false, want true", key)
+	}
+	if DigestFromKey(key) != digest {
+		t.Fatalf("DigestFromKey(%q) = %q, want %q", key, DigestFromKey(key), digest)
+	}
+	if IsDigestKey("plain-filename.txt") {
+		t.Fatal("IsDigestKey(plain-filename.txt) = true, want false")
+	}
+}
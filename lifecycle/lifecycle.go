@@ -0,0 +1,296 @@
+// Package lifecycle implements per-file TTL tracking, quota enforcement,
+// and background cleanup for uploaded objects, modeled on dwelling-upload's
+// `-keep-for` / cleanup-timer design.
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/NotAShelf/go-cdn/storage"
+)
+
+var metaBucket = []byte("metadata")
+
+// Metadata records the lifecycle state of a single stored object.
+type Metadata struct {
+	Key       string        `json:"key"`
+	Size      int64         `json:"size"`
+	CreatedAt time.Time     `json:"created_at"`
+	KeepFor   time.Duration `json:"keep_for"`
+}
+
+// ExpiresAt returns the time at which the object becomes eligible for
+// cleanup. A zero KeepFor means the object never expires.
+func (m Metadata) ExpiresAt() time.Time {
+	if m.KeepFor <= 0 {
+		return time.Time{}
+	}
+	return m.CreatedAt.Add(m.KeepFor)
+}
+
+// Expired reports whether the object has passed its expiry time as of now.
+func (m Metadata) Expired(now time.Time) bool {
+	expiresAt := m.ExpiresAt()
+	return !expiresAt.IsZero() && now.After(expiresAt)
+}
+
+// Manager tracks upload metadata in an embedded bbolt database and enforces
+// the configured TTL and storage_size quota against a Storage backend.
+type Manager struct {
+	db       *bolt.DB
+	storage  storage.Storage
+	keepFor  time.Duration
+	maxBytes int64
+	size     int64 // atomic, current total bytes tracked
+}
+
+// NewManager opens (or creates) the bbolt database at dbPath and returns a
+// Manager bound to backend. keepFor is the default retention applied to
+// uploads that don't specify their own, and maxBytes is the storage_size
+// quota (0 disables the quota).
+func NewManager(dbPath string, backend storage.Storage, keepFor time.Duration, maxBytes int64) (*Manager, error) {
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: opening metadata db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("lifecycle: initializing metadata bucket: %w", err)
+	}
+
+	m := &Manager{db: db, storage: backend, keepFor: keepFor, maxBytes: maxBytes}
+	if err := m.restoreSize(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// restoreSize seeds the in-memory size counter from the metadata already on
+// disk so it stays accurate across restarts without re-walking the backend.
+func (m *Manager) restoreSize() error {
+	return m.db.View(func(tx *bolt.Tx) error {
+		var total int64
+		err := tx.Bucket(metaBucket).ForEach(func(_, v []byte) error {
+			var meta Metadata
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return err
+			}
+			total += meta.Size
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("lifecycle: restoring size counter: %w", err)
+		}
+		atomic.StoreInt64(&m.size, total)
+		return nil
+	})
+}
+
+// Close closes the underlying metadata database.
+func (m *Manager) Close() error {
+	return m.db.Close()
+}
+
+// Size returns the current tracked total size in bytes.
+func (m *Manager) Size() int64 {
+	return atomic.LoadInt64(&m.size)
+}
+
+// Record stores metadata for a newly uploaded object, using keepFor as its
+// retention if non-zero, or the manager's default otherwise. Record is
+// idempotent per key: recording the same key twice (e.g. once from the
+// upload handler and once from a directory watcher racing it) only adds
+// the size delta against whatever was previously recorded, so the tracked
+// total never double-counts the same object.
+func (m *Manager) Record(key string, size int64, keepFor time.Duration) error {
+	if keepFor <= 0 {
+		keepFor = m.keepFor
+	}
+
+	meta := Metadata{Key: key, Size: size, CreatedAt: time.Now(), KeepFor: keepFor}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("lifecycle: marshaling metadata: %w", err)
+	}
+
+	var previousSize int64
+	hadPrevious := false
+	err = m.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		if existing := b.Get([]byte(key)); existing != nil {
+			var prev Metadata
+			if err := json.Unmarshal(existing, &prev); err == nil {
+				previousSize = prev.Size
+				hadPrevious = true
+			}
+		}
+		return b.Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("lifecycle: recording metadata: %w", err)
+	}
+
+	if hadPrevious {
+		atomic.AddInt64(&m.size, size-previousSize)
+	} else {
+		atomic.AddInt64(&m.size, size)
+	}
+	return nil
+}
+
+// Forget removes metadata for key without touching the backend. It is used
+// when an object has already been deleted.
+func (m *Manager) Forget(key string) error {
+	var size int64
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		if data := b.Get([]byte(key)); data != nil {
+			var meta Metadata
+			if err := json.Unmarshal(data, &meta); err == nil {
+				size = meta.Size
+			}
+		}
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("lifecycle: forgetting metadata: %w", err)
+	}
+
+	atomic.AddInt64(&m.size, -size)
+	return nil
+}
+
+// Get returns the metadata recorded for key.
+func (m *Manager) Get(key string) (Metadata, error) {
+	var meta Metadata
+	found := false
+
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &meta)
+	})
+	if err != nil {
+		return Metadata{}, fmt.Errorf("lifecycle: reading metadata: %w", err)
+	}
+	if !found {
+		return Metadata{}, fmt.Errorf("lifecycle: no metadata recorded for %q", key)
+	}
+	return meta, nil
+}
+
+// all returns every recorded Metadata entry, oldest first.
+func (m *Manager) all() ([]Metadata, error) {
+	var entries []Metadata
+	err := m.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).ForEach(func(_, v []byte) error {
+			var meta Metadata
+			if err := json.Unmarshal(v, &meta); err != nil {
+				return err
+			}
+			entries = append(entries, meta)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lifecycle: listing metadata: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+// Sweep deletes every expired object, then, if the tracked size still
+// exceeds maxBytes, evicts the oldest remaining objects until it no longer
+// does. It returns the keys it deleted. A single entry that fails to delete
+// (e.g. a metadata key that no longer resolves against the storage backend)
+// is skipped rather than aborting the rest of the sweep; any such failures
+// are reported together in the returned error.
+func (m *Manager) Sweep() ([]string, error) {
+	entries, err := m.all()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var deleted []string
+	var failures []string
+	remaining := entries[:0]
+	for _, meta := range entries {
+		if meta.Expired(now) {
+			if err := m.delete(meta.Key); err != nil {
+				failures = append(failures, err.Error())
+				continue
+			}
+			deleted = append(deleted, meta.Key)
+			continue
+		}
+		remaining = append(remaining, meta)
+	}
+
+	if m.maxBytes > 0 {
+		for _, meta := range remaining {
+			if m.Size() <= m.maxBytes {
+				break
+			}
+			if err := m.delete(meta.Key); err != nil {
+				failures = append(failures, err.Error())
+				continue
+			}
+			deleted = append(deleted, meta.Key)
+		}
+	}
+
+	if len(failures) > 0 {
+		return deleted, fmt.Errorf("lifecycle: sweep failed for %d object(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return deleted, nil
+}
+
+func (m *Manager) delete(key string) error {
+	if err := m.storage.Delete(context.Background(), key); err != nil {
+		return fmt.Errorf("lifecycle: deleting expired object %q: %w", key, err)
+	}
+	return m.Forget(key)
+}
+
+// Run starts a background goroutine that calls Sweep every interval until
+// stop is closed, logging the outcome of each pass.
+func (m *Manager) Run(logger *logrus.Logger, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := m.Sweep()
+				if err != nil {
+					logger.Errorf("Error sweeping expired uploads: %v", err)
+				}
+				if len(deleted) > 0 {
+					logger.Infof("Cleanup swept %d expired/evicted object(s)", len(deleted))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,57 @@
+package lifecycle
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NotAShelf/go-cdn/storage"
+)
+
+func TestSweepEvictsOldestFirstUnderQuota(t *testing.T) {
+	backend, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	m, err := NewManager(filepath.Join(t.TempDir(), "meta.db"), backend, 0, 150)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Close()
+
+	ctx := context.Background()
+	for _, key := range []string{"oldest.txt", "middle.txt", "newest.txt"} {
+		if err := backend.Put(ctx, key, strings.NewReader("x"), 1); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+	}
+	// CreatedAt ordering can't be set directly without touching unexported
+	// fields, so space the Record calls out to keep it strictly increasing,
+	// matching how uploads actually arrive in production.
+	if err := m.Record("oldest.txt", 100, 0); err != nil {
+		t.Fatalf("Record oldest: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := m.Record("middle.txt", 100, 0); err != nil {
+		t.Fatalf("Record middle: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := m.Record("newest.txt", 100, 0); err != nil {
+		t.Fatalf("Record newest: %v", err)
+	}
+
+	deleted, err := m.Sweep()
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if len(deleted) != 2 || deleted[0] != "oldest.txt" || deleted[1] != "middle.txt" {
+		t.Fatalf("expected [oldest.txt middle.txt] evicted in that order, got %v", deleted)
+	}
+	if m.Size() != 100 {
+		t.Fatalf("expected 100 bytes remaining, got %d", m.Size())
+	}
+}
@@ -0,0 +1,83 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// WatchDirectory watches dir for file creation and removal using inotify
+// and keeps the Manager's in-memory size counter in sync, so the
+// storage_size quota can be checked without re-walking the tree on every
+// upload. It only applies to the local filesystem backend; remote backends
+// (S3, SeaweedFS) update the counter directly via Record/Forget instead.
+func (m *Manager) WatchDirectory(logger *logrus.Logger, dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				m.handleWatchEvent(logger, dir, event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorf("Watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (m *Manager) handleWatchEvent(logger *logrus.Logger, dir string, event fsnotify.Event) {
+	// fsnotify reports event.Name relative to the watched directory's own
+	// path (e.g. "uploads/foo.txt"), but every other Manager call site keys
+	// metadata by the bare storage key ("foo.txt"). Strip the prefix so this
+	// doesn't create a second, bogus entry for every upload.
+	key, err := filepath.Rel(dir, event.Name)
+	if err != nil {
+		logger.Errorf("Error resolving watched file %s relative to %s: %v", event.Name, dir, err)
+		return
+	}
+	key = filepath.ToSlash(key)
+
+	switch {
+	case event.Op&fsnotify.Remove == fsnotify.Remove:
+		// The file is already gone from disk; drop its tracked metadata and
+		// size if it wasn't already removed through Manager.Sweep/Forget.
+		if _, err := m.Get(key); err == nil {
+			if err := m.Forget(key); err != nil {
+				logger.Errorf("Error forgetting removed file %s: %v", key, err)
+			}
+		}
+	case event.Op&fsnotify.Create == fsnotify.Create:
+		info, err := os.Stat(event.Name)
+		if err != nil || info.IsDir() {
+			return
+		}
+		// Record is idempotent per key, so this is safe to call even when it
+		// races CDNHandler's own post-upload Record call for the same file
+		// (e.g. while a scan is still in progress) — whichever call lands
+		// second only adjusts the size delta instead of double-counting it.
+		// This also covers uploads created outside of handlePost entirely
+		// (e.g. restored from a backup), which still count against the quota.
+		if err := m.Record(key, info.Size(), 0); err != nil {
+			logger.Errorf("Error recording watched file %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NotAShelf/go-cdn/storage"
+)
+
+func TestWatchDirectoryRecordRaceDoesNotDoubleCount(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	m, err := NewManager(filepath.Join(t.TempDir(), "meta.db"), backend, 0, 0)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	if err := m.WatchDirectory(logger, dir); err != nil {
+		t.Fatalf("WatchDirectory: %v", err)
+	}
+
+	const content = "hello world"
+	ctx := context.Background()
+	if err := backend.Put(ctx, "foo.txt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Simulate the upload handler's own post-scan Record call racing the
+	// watcher's inotify-driven Record for the same key.
+	if err := m.Record("foo.txt", int64(len(content)), 0); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	// Give the watcher goroutine a chance to observe and record the Create
+	// event, whether that lands before or after the explicit Record above.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if m.Size() == int64(len(content)) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got, want := m.Size(), int64(len(content)); got != want {
+		t.Fatalf("Size() = %d, want %d (watcher Record should not double-count an explicit Record for the same key)", got, want)
+	}
+}
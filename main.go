@@ -11,21 +11,44 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/NotAShelf/go-cdn/auth"
+	"github.com/NotAShelf/go-cdn/dedup"
+	"github.com/NotAShelf/go-cdn/lifecycle"
+	"github.com/NotAShelf/go-cdn/metrics"
+	"github.com/NotAShelf/go-cdn/scanner"
+	"github.com/NotAShelf/go-cdn/storage"
+	"github.com/NotAShelf/go-cdn/tus"
 )
 
 // Config represents the configuration structure
 type Config struct {
-	Port            string   `json:"port"`
-	MaxUploadSize   int64    `json:"max_upload_size"`
-	Heartbeat       Duration `json:"heartbeat"`
-	RequireAuth     bool     `json:"require_auth"`
-	AuthUsername    string   `json:"auth_username"`
-	AuthPassword    string   `json:"auth_password"`
-	UploadDirectory string   `json:"upload_directory"`
+	Port            string         `json:"port"`
+	MaxUploadSize   int64          `json:"max_upload_size"`
+	Heartbeat       Duration       `json:"heartbeat"`
+	Storage         storage.Config `json:"storage"`
+	KeepFor         Duration       `json:"keep_for"`
+	CleanupInterval Duration       `json:"cleanup_interval"`
+	StorageSize     int64          `json:"storage_size"`
+	MetadataPath    string         `json:"metadata_path"`
+	TusDirectory    string         `json:"tus_directory"`
+	Auth            auth.Config    `json:"auth"`
+	Scanner         scanner.Config `json:"scanner"`
+	Log             LogConfig      `json:"log"`
+}
+
+// LogConfig configures structured access logging.
+type LogConfig struct {
+	// File, if set, rotates JSON access logs to this path in addition to
+	// stdout.
+	File string `json:"file"`
 }
 
 // Duration is a custom type for decoding time.Duration from JSON
@@ -49,29 +72,121 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 
 // CDNHandler handles HTTP requests to the CDN server
 type CDNHandler struct {
-	Config Config
-	Logger *logrus.Logger
+	Config    Config
+	Logger    *logrus.Logger
+	Storage   storage.Storage
+	Lifecycle *lifecycle.Manager
+	Tus       *tus.Store
+	Auth      *auth.Authorizer
+	Scanner   scanner.Scanner
+	Metrics   *metrics.Metrics
+	Dedup     *dedup.AliasStore
 }
 
 // ServeHTTP serves HTTP requests
 func (c *CDNHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/meta/") {
+		c.handleMeta(w, r)
+		return
+	}
+
+	if c.Tus != nil {
+		switch {
+		case r.Method == http.MethodOptions:
+			c.handleTusOptions(w, r)
+			return
+		case r.Method == http.MethodPost && r.Header.Get("Tus-Resumable") != "":
+			c.handleTusCreate(w, r)
+			return
+		case r.Method == http.MethodPatch:
+			c.handleTusPatch(w, r)
+			return
+		case r.Method == http.MethodHead && r.Header.Get("Tus-Resumable") != "":
+			c.handleTusHead(w, r)
+			return
+		}
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		c.handleGet(w, r)
 	case http.MethodPost:
 		c.handlePost(w, r)
+	case http.MethodDelete:
+		c.handleDelete(w, r)
 	default:
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// handleMeta serves GET /meta/<file>, reporting the remaining TTL for an
+// uploaded object.
+func (c *CDNHandler) handleMeta(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/meta/")
+
+	if c.Lifecycle == nil {
+		http.Error(w, "Lifecycle Tracking Disabled", http.StatusNotImplemented)
+		return
+	}
+
+	storageKey := key
+	if c.Dedup != nil {
+		resolved, err := c.resolveContentAddressedKey(key)
+		if err != nil {
+			c.Logger.Errorf("Error resolving content-addressed key: %v", err)
+			http.Error(w, "File Not Found", http.StatusNotFound)
+			return
+		}
+		storageKey = resolved
+	}
+
+	meta, err := c.Lifecycle.Get(storageKey)
+	if err != nil {
+		c.Logger.Errorf("Error reading metadata for %s: %v", key, err)
+		http.Error(w, "File Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file":       key,
+		"size":       meta.Size,
+		"created_at": meta.CreatedAt,
+		"expires_at": meta.ExpiresAt(),
+	})
+}
+
 // handleGet handles GET requests
 func (c *CDNHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	c.Logger.Infof("Received GET request for URL: %s", r.URL.Path)
 
 	// Serve file for download
-	filePath := filepath.Join(c.Config.UploadDirectory, r.URL.Path)
-	file, err := os.Open(filePath)
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	if expires := r.URL.Query().Get("expires"); expires != "" {
+		if !c.verifySignedDownload(key, expires, r.URL.Query().Get("signature")) {
+			http.Error(w, "Invalid Or Expired Link", http.StatusForbidden)
+			return
+		}
+	}
+
+	storageKey := key
+	if c.Dedup != nil {
+		resolved, err := c.resolveContentAddressedKey(key)
+		if err != nil {
+			c.Logger.Errorf("Error resolving content-addressed key: %v", err)
+			http.Error(w, "File Not Found", http.StatusNotFound)
+			return
+		}
+		storageKey = resolved
+
+		if setContentAddressedHeaders(w, r, storageKey) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	file, err := c.Storage.Get(r.Context(), storageKey)
 	if err != nil {
 		c.Logger.Errorf("Error opening file: %v", err)
 		http.Error(w, "File Not Found", http.StatusNotFound)
@@ -79,9 +194,17 @@ func (c *CDNHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	if c.Lifecycle != nil {
+		if meta, err := c.Lifecycle.Get(storageKey); err == nil {
+			if expiresAt := meta.ExpiresAt(); !expiresAt.IsZero() {
+				w.Header().Set("X-Expires-At", expiresAt.Format(time.RFC3339))
+			}
+		}
+	}
+
 	// Set the appropriate Content-Type header based on file extension
 	contentType := "application/octet-stream"
-	switch filepath.Ext(filePath) {
+	switch filepath.Ext(key) {
 	case ".jpg", ".jpeg":
 		contentType = "image/jpeg"
 	case ".png":
@@ -91,19 +214,41 @@ func (c *CDNHandler) handleGet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", contentType)
-	if _, err := io.Copy(w, file); err != nil {
+	written, err := io.Copy(w, file)
+	if err != nil {
 		c.Logger.Errorf("Error copying file: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	if c.Metrics != nil {
+		c.Metrics.BytesDownloaded.Add(float64(written))
+	}
 
 	c.Logger.Infof("File downloaded successfully: %s", r.URL.Path)
 }
 
+// verifySignedDownload reports whether a "?expires=...&signature=..." query
+// string authorizes downloading key.
+func (c *CDNHandler) verifySignedDownload(key, expires, signature string) bool {
+	if c.Auth == nil {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return false
+	}
+	return c.Auth.VerifyDownloadURL(key, expiresAt, signature)
+}
+
 // handlePost handles POST requests
 func (c *CDNHandler) handlePost(w http.ResponseWriter, r *http.Request) {
 	c.Logger.Info("Received POST request")
 
+	if c.Auth != nil && !c.Auth.Authorize(r, auth.ScopeUpload) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	// Validate request size
 	r.Body = http.MaxBytesReader(w, r.Body, c.Config.MaxUploadSize)
 	if err := r.ParseMultipartForm(c.Config.MaxUploadSize); err != nil {
@@ -121,36 +266,139 @@ func (c *CDNHandler) handlePost(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// Create the upload directory if it doesn't exist
-	uploadDir := c.Config.UploadDirectory
-	if uploadDir == "" {
-		uploadDir = "uploads"
+	// Stream the uploaded file straight to the configured storage backend,
+	// or through the content-addressing path when deduplication is enabled
+	storageKey := handler.Filename
+	skipScan := false
+	if c.Dedup != nil {
+		key, duplicate, err := c.storeContentAddressed(r.Context(), file, handler.Filename)
+		if err != nil {
+			c.Logger.Errorf("Error storing file: %v", err)
+			http.Error(w, "Error storing file", http.StatusInternalServerError)
+			return
+		}
+		storageKey = key
+		// A duplicate resolves to content that was already scanned (and
+		// kept) when it was first uploaded; re-scanning it here would risk
+		// a reject deleting the shared object out from under every other
+		// alias that already points at it.
+		skipScan = duplicate
+	} else if err := c.Storage.Put(r.Context(), handler.Filename, file, handler.Size); err != nil {
+		c.Logger.Errorf("Error storing file: %v", err)
+		http.Error(w, "Error storing file", http.StatusInternalServerError)
+		return
 	}
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		c.Logger.Errorf("Error creating upload directory: %v", err)
-		http.Error(w, "Error creating upload directory", http.StatusInternalServerError)
+
+	if c.Metrics != nil {
+		c.Metrics.BytesUploaded.Add(float64(handler.Size))
+	}
+
+	if !skipScan {
+		if rejected, err := c.scanUpload(r.Context(), w, storageKey); err != nil {
+			c.Logger.Errorf("Error scanning upload: %v", err)
+			http.Error(w, "Error scanning upload", http.StatusInternalServerError)
+			return
+		} else if rejected {
+			if c.Dedup != nil {
+				if err := c.Dedup.Delete(handler.Filename); err != nil {
+					c.Logger.Errorf("Error removing alias for rejected upload %s: %v", handler.Filename, err)
+				}
+			}
+			return
+		}
+	}
+
+	if c.Lifecycle != nil {
+		if err := c.Lifecycle.Record(storageKey, handler.Size, 0); err != nil {
+			c.Logger.Errorf("Error recording upload metadata: %v", err)
+		}
+	}
+
+	c.Logger.Infof("File uploaded successfully: %s", handler.Filename)
+
+	if c.Auth == nil {
+		fmt.Fprint(w, "File uploaded successfully")
 		return
 	}
 
-	// Create the file in the upload directory
-	filePath := filepath.Join(uploadDir, handler.Filename)
-	newFile, err := os.Create(filePath)
-	if err != nil {
-		c.Logger.Errorf("Error creating file: %v", err)
-		http.Error(w, "Error creating file", http.StatusInternalServerError)
+	downloadURL := "/" + handler.Filename
+	if c.Auth != nil {
+		downloadURL = c.Auth.SignDownloadURL(handler.Filename)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":           true,
+		"path":         "/" + handler.Filename,
+		"delete_token": c.Auth.IssueDeleteToken(handler.Filename),
+		"download_url": downloadURL,
+	})
+}
+
+// handleDelete handles DELETE requests, authorizing either via a bearer
+// token with the delete scope or a per-upload delete token issued at
+// upload time.
+func (c *CDNHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	c.Logger.Infof("Received DELETE request for: %s", key)
+
+	if c.Auth == nil {
+		http.Error(w, "Delete Not Configured", http.StatusNotImplemented)
 		return
 	}
-	defer newFile.Close()
 
-	// Copy the uploaded file to the new file
-	if _, err := io.Copy(newFile, file); err != nil {
-		c.Logger.Errorf("Error copying file: %v", err)
-		http.Error(w, "Error copying file", http.StatusInternalServerError)
+	// With bearer tokens disabled Authorize admits every request, so only
+	// the per-upload delete token can authorize a delete in that mode.
+	authorized := c.Config.Auth.Enabled && c.Auth.Authorize(r, auth.ScopeDelete)
+	if !authorized {
+		if token := r.Header.Get("X-Delete-Token"); token != "" {
+			authorized = c.Auth.VerifyDeleteToken(key, token)
+		}
+	}
+	if !authorized {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	c.Logger.Infof("File uploaded successfully: %s", handler.Filename)
-	fmt.Fprint(w, "File uploaded successfully")
+	storageKey := key
+	if c.Dedup != nil {
+		resolved, err := c.resolveContentAddressedKey(key)
+		if err != nil {
+			c.Logger.Errorf("Error resolving content-addressed key: %v", err)
+			http.Error(w, "File Not Found", http.StatusNotFound)
+			return
+		}
+		storageKey = resolved
+
+		if err := c.Dedup.Delete(key); err != nil {
+			c.Logger.Errorf("Error removing alias for %s: %v", key, err)
+		}
+
+		if refs, err := c.Dedup.RefCount(storageKey); err != nil {
+			c.Logger.Errorf("Error counting remaining aliases for %s: %v", storageKey, err)
+		} else if refs > 0 {
+			// Other filenames still reference this object; only the alias
+			// for this one is removed, the shared content stays in place.
+			c.Logger.Infof("Removed alias %s, %s still has %d reference(s)", key, storageKey, refs)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	if err := c.Storage.Delete(r.Context(), storageKey); err != nil {
+		c.Logger.Errorf("Error deleting file: %v", err)
+		http.Error(w, "Error deleting file", http.StatusInternalServerError)
+		return
+	}
+
+	if c.Lifecycle != nil {
+		if err := c.Lifecycle.Forget(storageKey); err != nil {
+			c.Logger.Errorf("Error forgetting metadata for %s: %v", storageKey, err)
+		}
+	}
+
+	c.Logger.Infof("File deleted successfully: %s", key)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func main() {
@@ -158,12 +406,11 @@ func main() {
 	configPath := flag.String("config", "config.json", "Path to the configuration file")
 	flag.Parse()
 
-	// Initialize logrus logger
+	// Initialize logrus logger. Access logs are emitted as JSON so they can
+	// be shipped to log aggregators; bootstrap messages use the same
+	// formatter for consistency.
 	logger := logrus.New()
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp:   true,
-		TimestampFormat: "2006-01-02 15:04:05",
-	})
+	logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: "2006-01-02 15:04:05"})
 
 	// Read the configuration file
 	configFile, err := os.Open(*configPath)
@@ -179,6 +426,17 @@ func main() {
 		logger.Fatalf("Error decoding configuration file: %v", err)
 	}
 
+	// Mirror logs to a rotated file in addition to stdout when configured
+	if config.Log.File != "" {
+		logger.SetOutput(io.MultiWriter(os.Stdout, &lumberjack.Logger{
+			Filename:   config.Log.File,
+			MaxSize:    100, // megabytes
+			MaxBackups: 3,
+			MaxAge:     28, // days
+			Compress:   true,
+		}))
+	}
+
 	// Start a goroutine to restart the server periodically
 	if config.Heartbeat > 0 {
 		go func() {
@@ -202,19 +460,89 @@ func main() {
 
 // startServer creates and starts the HTTP server
 func startServer(config *Config, logger *logrus.Logger) *http.Server {
+	// Build the storage backend selected by config.Storage.Type
+	backend, err := storage.New(config.Storage)
+	if err != nil {
+		logger.Fatalf("Error initializing storage backend: %v", err)
+	}
+
 	// Create a new CDNHandler with the configuration
 	cdnHandler := &CDNHandler{
-		Config: *config,
-		Logger: logger,
+		Config:  *config,
+		Logger:  logger,
+		Storage: backend,
+	}
+
+	if manager, err := newLifecycleManager(config, backend, logger); err != nil {
+		logger.Errorf("Error initializing lifecycle manager: %v", err)
+	} else if manager != nil {
+		cdnHandler.Lifecycle = manager
+	}
+
+	if config.TusDirectory != "" {
+		tusStore, err := tus.NewStore(config.TusDirectory)
+		if err != nil {
+			logger.Errorf("Error initializing tus store: %v", err)
+		} else {
+			cdnHandler.Tus = tusStore
+		}
+	}
+
+	authorizer, err := auth.New(config.Auth)
+	if err != nil {
+		logger.Errorf("Error initializing auth subsystem: %v", err)
+	} else {
+		cdnHandler.Auth = authorizer
+	}
+
+	scan, err := scanner.New(config.Scanner)
+	if err != nil {
+		logger.Errorf("Error initializing scanner: %v", err)
+	} else {
+		cdnHandler.Scanner = scan
+	}
+
+	if config.Storage.ContentAddressed {
+		aliasDB := config.Storage.AliasDB
+		if aliasDB == "" {
+			aliasDB = "content-aliases.db"
+		}
+		aliasStore, err := dedup.NewAliasStore(aliasDB)
+		if err != nil {
+			logger.Errorf("Error initializing content-addressed alias store: %v", err)
+		} else {
+			cdnHandler.Dedup = aliasStore
+		}
+	}
+
+	cdnHandler.Metrics = metrics.New()
+	if cdnHandler.Lifecycle != nil {
+		go reportStorageSize(cdnHandler.Metrics, cdnHandler.Lifecycle)
+	}
+
+	// /metrics is served outside of CDNHandler so scrapes don't show up in
+	// access logs or request metrics
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", cdnHandler.Metrics.Handler())
+	mux.Handle("/", cdnHandler.withObservability(cdnHandler))
+
+	// Scanning runs synchronously inside handlePost/finalizeTusUpload before
+	// a response is written, and VirusTotal in particular can take minutes
+	// to return a verdict. A fixed WriteTimeout would cut that connection
+	// out from under the client mid-scan, so disable it whenever a scanner
+	// is configured.
+	writeTimeout := 10 * time.Second
+	if config.Scanner.Type != "" {
+		writeTimeout = 0
 	}
 
 	// Create a new HTTP server
 	server := &http.Server{
 		Addr:         ":" + config.Port,
-		Handler:      cdnHandler,
+		Handler:      mux,
 		ErrorLog:     log.New(logger.Writer(), "", 0),
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: writeTimeout,
 	}
 
 	// Start the server in a separate goroutine
@@ -228,6 +556,54 @@ func startServer(config *Config, logger *logrus.Logger) *http.Server {
 	return server
 }
 
+// newLifecycleManager builds the lifecycle.Manager used for TTL tracking,
+// quota enforcement and background cleanup, if config.MetadataPath is set.
+// It returns a nil Manager when lifecycle tracking is not configured.
+func newLifecycleManager(config *Config, backend storage.Storage, logger *logrus.Logger) (*lifecycle.Manager, error) {
+	if config.MetadataPath == "" {
+		return nil, nil
+	}
+
+	manager, err := lifecycle.NewManager(
+		config.MetadataPath,
+		backend,
+		time.Duration(config.KeepFor),
+		config.StorageSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating lifecycle manager: %w", err)
+	}
+
+	interval := time.Duration(config.CleanupInterval)
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	manager.Run(logger, interval, make(chan struct{}))
+
+	storageType := config.Storage.Type
+	if storageType == "" || storageType == "local" {
+		dir := config.Storage.Directory
+		if dir == "" {
+			dir = "uploads"
+		}
+		if err := manager.WatchDirectory(logger, dir); err != nil {
+			logger.Errorf("Error watching upload directory for size tracking: %v", err)
+		}
+	}
+
+	return manager, nil
+}
+
+// reportStorageSize periodically copies the lifecycle manager's tracked
+// size into the storage-bytes-used gauge.
+func reportStorageSize(m *metrics.Metrics, lm *lifecycle.Manager) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.StorageBytesUsed.Set(float64(lm.Size()))
+	}
+}
+
 // stopServer stops the HTTP server
 func stopServer(server *http.Server, logger *logrus.Logger) {
 	logger.Info("Stopping CDN server")
@@ -0,0 +1,74 @@
+// Package metrics registers the Prometheus collectors exposed by go-cdn's
+// /metrics endpoint, borrowing the gin-prometheus pattern of one counter
+// per method/status pair plus latency histograms and in-flight gauges.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds every collector go-cdn exports.
+type Metrics struct {
+	RequestsTotal    *prometheus.CounterVec
+	BytesUploaded    prometheus.Counter
+	BytesDownloaded  prometheus.Counter
+	UploadDuration   prometheus.Histogram
+	InFlightRequests prometheus.Gauge
+	StorageBytesUsed prometheus.Gauge
+
+	registry *prometheus.Registry
+}
+
+// New creates and registers every collector on a fresh registry.
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "go_cdn_requests_total",
+			Help: "Total number of HTTP requests handled, by method and status code.",
+		}, []string{"method", "status"}),
+		BytesUploaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "go_cdn_bytes_uploaded_total",
+			Help: "Total number of bytes received from uploads.",
+		}),
+		BytesDownloaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "go_cdn_bytes_downloaded_total",
+			Help: "Total number of bytes served for downloads.",
+		}),
+		UploadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "go_cdn_upload_duration_seconds",
+			Help:    "Time taken to handle upload requests.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		InFlightRequests: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "go_cdn_in_flight_requests",
+			Help: "Number of HTTP requests currently being handled.",
+		}),
+		StorageBytesUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "go_cdn_storage_bytes_used",
+			Help: "Total bytes currently tracked by the lifecycle manager, if enabled.",
+		}),
+		registry: registry,
+	}
+
+	registry.MustRegister(
+		m.RequestsTotal,
+		m.BytesUploaded,
+		m.BytesDownloaded,
+		m.UploadDuration,
+		m.InFlightRequests,
+		m.StorageBytesUsed,
+	)
+
+	return m
+}
+
+// Handler returns the http.Handler that serves /metrics in the Prometheus
+// text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newRequestID returns a short random identifier used to correlate a
+// request's log lines and any errors it produces.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder wraps http.ResponseWriter to capture the status code and
+// byte count written, for access logging and metrics.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// requestIDKey is the context key under which the current request's ID is
+// stored.
+type requestIDKey struct{}
+
+func contextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// withObservability wraps next with structured access logging, a
+// per-request ID, and Prometheus request metrics.
+func (c *CDNHandler) withObservability(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := newRequestID()
+		w.Header().Set("X-Request-Id", requestID)
+		r = r.WithContext(contextWithRequestID(r.Context(), requestID))
+
+		if c.Metrics != nil {
+			c.Metrics.InFlightRequests.Inc()
+			defer c.Metrics.InFlightRequests.Dec()
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
+
+		c.Logger.WithFields(logrus.Fields{
+			"request_id":  requestID,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"bytes":       rec.bytes,
+			"duration_ms": duration.Milliseconds(),
+			"remote_addr": r.RemoteAddr,
+		}).Info("access")
+
+		if c.Metrics != nil {
+			c.Metrics.RequestsTotal.WithLabelValues(r.Method, strconv.Itoa(rec.status)).Inc()
+			if r.Method == http.MethodPost {
+				c.Metrics.UploadDuration.Observe(duration.Seconds())
+			}
+		}
+	})
+}
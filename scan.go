@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/NotAShelf/go-cdn/scanner"
+)
+
+// scanUpload runs the configured Scanner against the just-written object
+// and, if it comes back infected, enforces scanner.action. It returns true
+// if the caller should stop handling the request (a rejection response has
+// already been written).
+func (c *CDNHandler) scanUpload(ctx context.Context, w http.ResponseWriter, key string) (bool, error) {
+	if c.Scanner == nil {
+		return false, nil
+	}
+
+	file, err := c.Storage.Get(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("scan: reopening uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	verdict, err := c.Scanner.Scan(ctx, file)
+	if err != nil {
+		return false, fmt.Errorf("scan: scanning uploaded file: %w", err)
+	}
+
+	if verdict.Clean {
+		return false, nil
+	}
+
+	action := c.Config.Scanner.Action
+	if action == "" {
+		action = scanner.ActionReject
+	}
+
+	switch action {
+	case scanner.ActionTag:
+		if err := c.tagScanVerdict(ctx, key, verdict.Signature); err != nil {
+			c.Logger.Errorf("Error tagging scan verdict for %s: %v", key, err)
+		}
+		return false, nil
+	default:
+		if err := c.Storage.Delete(ctx, key); err != nil {
+			c.Logger.Errorf("Error deleting infected upload %s: %v", key, err)
+		}
+		if c.Lifecycle != nil {
+			if err := c.Lifecycle.Forget(key); err != nil {
+				c.Logger.Errorf("Error forgetting metadata for infected upload %s: %v", key, err)
+			}
+		}
+
+		c.Logger.Warnf("Rejected infected upload %s: %s", key, verdict.Signature)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":        false,
+			"error":     "infected",
+			"signature": verdict.Signature,
+		})
+		return true, nil
+	}
+}
+
+// tagScanVerdict stores verdict alongside the object as a small JSON
+// sidecar, reusing the Storage backend rather than introducing a separate
+// metadata store for this optional extra.
+func (c *CDNHandler) tagScanVerdict(ctx context.Context, key, signature string) error {
+	data, err := json.Marshal(map[string]string{"signature": signature})
+	if err != nil {
+		return fmt.Errorf("scan: marshaling verdict: %w", err)
+	}
+	if err := c.Storage.Put(ctx, key+".scan.json", bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("scan: storing verdict sidecar: %w", err)
+	}
+	return nil
+}
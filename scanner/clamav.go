@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize is the maximum number of bytes sent per INSTREAM chunk.
+const clamavChunkSize = 64 * 1024
+
+// ClamAVScanner scans files by streaming them to clamd over its INSTREAM
+// protocol (https://linux.die.net/man/8/clamd).
+type ClamAVScanner struct {
+	address string
+	dialer  net.Dialer
+}
+
+// NewClamAVScanner returns a ClamAVScanner that connects to clamd at
+// address (host:port).
+func NewClamAVScanner(address string) *ClamAVScanner {
+	return &ClamAVScanner{address: address}
+}
+
+// Scan streams r to clamd via INSTREAM and parses its verdict.
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("scanner: connecting to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, fmt.Errorf("scanner: sending INSTREAM command: %w", err)
+	}
+
+	buf := make([]byte, clamavChunkSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			size := make([]byte, 4)
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return Verdict{}, fmt.Errorf("scanner: writing chunk size: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return Verdict{}, fmt.Errorf("scanner: writing chunk: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Verdict{}, fmt.Errorf("scanner: reading file: %w", readErr)
+		}
+	}
+
+	// A zero-length chunk terminates the stream.
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Verdict{}, fmt.Errorf("scanner: sending terminating chunk: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Verdict{}, fmt.Errorf("scanner: reading clamd reply: %w", err)
+	}
+	reply = strings.TrimRight(reply, "\x00\r\n")
+
+	verdict := Verdict{ScannedAt: time.Now()}
+	if strings.HasSuffix(reply, "OK") {
+		verdict.Clean = true
+		return verdict, nil
+	}
+
+	if idx := strings.Index(reply, "FOUND"); idx != -1 {
+		verdict.Signature = strings.TrimSpace(strings.TrimPrefix(reply[:idx], "stream:"))
+		return verdict, nil
+	}
+
+	return Verdict{}, fmt.Errorf("scanner: unexpected clamd reply: %q", reply)
+}
@@ -0,0 +1,68 @@
+// Package scanner implements a pluggable malware-scanning stage run against
+// uploaded files before the upload response is sent, mirroring how
+// transfer.sh wires ClamAV and VirusTotal checks into its upload handler.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Verdict is the outcome of scanning a single file.
+type Verdict struct {
+	Clean     bool      `json:"clean"`
+	Signature string    `json:"signature,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Scanner inspects file contents read from r and reports whether they are
+// clean.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// Config selects and configures a Scanner.
+type Config struct {
+	// Type selects the backend: "" (disabled), "clamav", or "virustotal".
+	Type string `json:"type"`
+
+	// Address is the clamd TCP address (host:port) for the clamav backend.
+	Address string `json:"address"`
+
+	// APIKey authenticates against the VirusTotal v3 API.
+	APIKey string `json:"api_key"`
+
+	// Action determines what happens when a scan comes back infected:
+	// "reject" deletes the file and returns 422, "tag" records the verdict
+	// alongside the file and allows the upload to proceed.
+	Action string `json:"action"`
+}
+
+// ActionReject and ActionTag are the recognized values for Config.Action.
+const (
+	ActionReject = "reject"
+	ActionTag    = "tag"
+)
+
+// New builds the Scanner selected by cfg.Type. A nil Scanner and nil error
+// are returned when cfg.Type is empty, meaning scanning is disabled.
+func New(cfg Config) (Scanner, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case "clamav":
+		if cfg.Address == "" {
+			return nil, fmt.Errorf("scanner: clamav backend requires scanner.address")
+		}
+		return NewClamAVScanner(cfg.Address), nil
+	case "virustotal":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("scanner: virustotal backend requires scanner.api_key")
+		}
+		return NewVirusTotalScanner(cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("scanner: unknown backend type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,170 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+const virusTotalBaseURL = "https://www.virustotal.com/api/v3"
+
+// VirusTotalScanner scans files via the VirusTotal v3 API: it uploads the
+// file, then polls the returned analysis until VirusTotal has a verdict.
+type VirusTotalScanner struct {
+	apiKey      string
+	client      *http.Client
+	pollEvery   time.Duration
+	pollTimeout time.Duration
+}
+
+// NewVirusTotalScanner returns a VirusTotalScanner authenticated with
+// apiKey.
+func NewVirusTotalScanner(apiKey string) *VirusTotalScanner {
+	return &VirusTotalScanner{
+		apiKey:      apiKey,
+		client:      &http.Client{Timeout: 60 * time.Second},
+		pollEvery:   3 * time.Second,
+		pollTimeout: 5 * time.Minute,
+	}
+}
+
+type vtUploadResponse struct {
+	Data struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+type vtAnalysisResponse struct {
+	Data struct {
+		Attributes struct {
+			Status string `json:"status"`
+			Stats  struct {
+				Malicious  int `json:"malicious"`
+				Suspicious int `json:"suspicious"`
+			} `json:"stats"`
+			Results map[string]struct {
+				Category string `json:"category"`
+				Result   string `json:"result"`
+			} `json:"results"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Scan uploads r to VirusTotal and polls until analysis completes.
+func (s *VirusTotalScanner) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	analysisID, err := s.upload(ctx, r)
+	if err != nil {
+		return Verdict{}, err
+	}
+	return s.pollAnalysis(ctx, analysisID)
+}
+
+func (s *VirusTotalScanner) upload(ctx context.Context, r io.Reader) (string, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := mw.CreateFormFile("file", "upload")
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, virusTotalBaseURL+"/files", pr)
+	if err != nil {
+		return "", fmt.Errorf("scanner: building virustotal request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("x-apikey", s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("scanner: uploading to virustotal: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("scanner: virustotal upload returned %s", resp.Status)
+	}
+
+	var uploadResp vtUploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+		return "", fmt.Errorf("scanner: decoding virustotal upload response: %w", err)
+	}
+	return uploadResp.Data.ID, nil
+}
+
+func (s *VirusTotalScanner) pollAnalysis(ctx context.Context, analysisID string) (Verdict, error) {
+	deadline := time.Now().Add(s.pollTimeout)
+
+	for {
+		analysis, err := s.fetchAnalysis(ctx, analysisID)
+		if err != nil {
+			return Verdict{}, err
+		}
+
+		if analysis.Data.Attributes.Status == "completed" {
+			verdict := Verdict{ScannedAt: time.Now()}
+			stats := analysis.Data.Attributes.Stats
+			if stats.Malicious == 0 && stats.Suspicious == 0 {
+				verdict.Clean = true
+				return verdict, nil
+			}
+
+			for engine, result := range analysis.Data.Attributes.Results {
+				if result.Category == "malicious" {
+					verdict.Signature = fmt.Sprintf("%s: %s", engine, result.Result)
+					break
+				}
+			}
+			if verdict.Signature == "" {
+				verdict.Signature = fmt.Sprintf("virustotal: %d engines flagged this file", stats.Malicious+stats.Suspicious)
+			}
+			return verdict, nil
+		}
+
+		if time.Now().After(deadline) {
+			return Verdict{}, fmt.Errorf("scanner: virustotal analysis %s did not complete within %s", analysisID, s.pollTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return Verdict{}, ctx.Err()
+		case <-time.After(s.pollEvery):
+		}
+	}
+}
+
+func (s *VirusTotalScanner) fetchAnalysis(ctx context.Context, analysisID string) (vtAnalysisResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, virusTotalBaseURL+"/analyses/"+analysisID, nil)
+	if err != nil {
+		return vtAnalysisResponse{}, fmt.Errorf("scanner: building virustotal request: %w", err)
+	}
+	req.Header.Set("x-apikey", s.apiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return vtAnalysisResponse{}, fmt.Errorf("scanner: polling virustotal: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return vtAnalysisResponse{}, fmt.Errorf("scanner: virustotal analysis poll returned %s", resp.Status)
+	}
+
+	var analysis vtAnalysisResponse
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
+		return vtAnalysisResponse{}, fmt.Errorf("scanner: decoding virustotal analysis response: %w", err)
+	}
+	return analysis, nil
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage stores objects as files beneath a root directory on the
+// local filesystem.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a LocalStorage rooted at dir, creating dir if it
+// does not already exist.
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("storage: creating upload directory: %w", err)
+	}
+	return &LocalStorage{root: dir}, nil
+}
+
+// path resolves key to a filesystem path beneath l.root, rejecting keys
+// (such as a client-supplied filename containing "..") that would resolve
+// outside of it.
+func (l *LocalStorage) path(key string) (string, error) {
+	full := filepath.Join(l.root, filepath.FromSlash(key))
+	if full != l.root && !strings.HasPrefix(full, l.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("storage: key %q escapes storage root", key)
+	}
+	return full, nil
+}
+
+// Put streams r to disk under key, creating any parent directories needed.
+func (l *LocalStorage) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("storage: creating parent directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("storage: creating file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("storage: writing file: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file stored under key.
+func (l *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("storage: opening file: %w", err)
+	}
+	return f, nil
+}
+
+// Stat returns metadata about the file stored under key.
+func (l *LocalStorage) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: stat file: %w", err)
+	}
+	return ObjectInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+// Delete removes the file stored under key.
+func (l *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("storage: deleting file: %w", err)
+	}
+	return nil
+}
+
+// List walks the root directory and returns every file whose key starts
+// with prefix.
+func (l *LocalStorage) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.Walk(l.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if prefix != "" && !hasPrefix(key, prefix) {
+			return nil
+		}
+
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: listing files: %w", err)
+	}
+	return objects, nil
+}
+
+func hasPrefix(key, prefix string) bool {
+	return len(key) >= len(prefix) && key[:len(prefix)] == prefix
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageRejectsPathTraversal(t *testing.T) {
+	l, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	err = l.Put(context.Background(), "../../etc/passwd", strings.NewReader("pwned"), 5)
+	if err == nil {
+		t.Fatal("expected Put to reject a key escaping the storage root")
+	}
+}
+
+func TestLocalStoragePutGetRoundTrip(t *testing.T) {
+	l, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := l.Put(ctx, "foo.txt", strings.NewReader("hello"), 5); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	r, err := l.Get(ctx, "foo.txt")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("Get content = %q, want %q", data, "hello")
+	}
+}
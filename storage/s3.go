@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Storage stores objects in an S3-compatible bucket.
+type S3Storage struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Storage builds an S3Storage from cfg. Endpoint may be left empty to
+// use AWS's default endpoint resolution, or set to point at a compatible
+// provider (MinIO, Backblaze B2, etc.).
+func NewS3Storage(cfg Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("storage: s3 backend requires storage.bucket")
+	}
+
+	client := s3.New(s3.Options{
+		Region:       cfg.Region,
+		UsePathStyle: cfg.Endpoint != "",
+		Credentials:  credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
+		BaseEndpoint: endpointPtr(cfg),
+	})
+
+	return &S3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func endpointPtr(cfg Config) *string {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	scheme := "https"
+	if !cfg.UseSSL {
+		scheme = "http"
+	}
+	endpoint := fmt.Sprintf("%s://%s", scheme, cfg.Endpoint)
+	return &endpoint
+}
+
+// Put uploads r to the bucket under key.
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          r,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: s3 put object: %w", err)
+	}
+	return nil
+}
+
+// Get opens the object stored under key for reading.
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 get object: %w", err)
+	}
+	return out.Body, nil
+}
+
+// Stat returns metadata about the object stored under key.
+func (s *S3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: s3 head object: %w", err)
+	}
+
+	info := ObjectInfo{Key: key}
+	if out.ContentLength != nil {
+		info.Size = *out.ContentLength
+	}
+	if out.LastModified != nil {
+		info.ModTime = *out.LastModified
+	}
+	return info, nil
+}
+
+// Delete removes the object stored under key.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: s3 delete object: %w", err)
+	}
+	return nil
+}
+
+// List returns every object in the bucket whose key starts with prefix.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("storage: s3 list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			info := ObjectInfo{Key: key}
+			if obj.Size != nil {
+				info.Size = *obj.Size
+			}
+			if obj.LastModified != nil {
+				info.ModTime = *obj.LastModified
+			}
+			objects = append(objects, info)
+		}
+	}
+
+	return objects, nil
+}
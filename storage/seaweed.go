@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SeaweedStorage stores objects through a SeaweedFS filer's HTTP API
+// (https://github.com/seaweedfs/seaweedfs/wiki/Filer-Server-API), addressing
+// objects by path rather than dealing with volume/needle assignment
+// directly.
+type SeaweedStorage struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+}
+
+// NewSeaweedStorage builds a SeaweedStorage pointed at a filer endpoint.
+func NewSeaweedStorage(cfg Config) (*SeaweedStorage, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("storage: seaweedfs backend requires storage.endpoint")
+	}
+
+	scheme := "http"
+	if cfg.UseSSL {
+		scheme = "https"
+	}
+
+	return &SeaweedStorage{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		endpoint: fmt.Sprintf("%s://%s", scheme, cfg.Endpoint),
+		bucket:   strings.Trim(cfg.Bucket, "/"),
+	}, nil
+}
+
+func (s *SeaweedStorage) url(key string) string {
+	if s.bucket == "" {
+		return fmt.Sprintf("%s/%s", s.endpoint, strings.TrimPrefix(key, "/"))
+	}
+	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, strings.TrimPrefix(key, "/"))
+}
+
+// Put uploads r as a multipart file to the filer path for key.
+func (s *SeaweedStorage) Put(ctx context.Context, key string, r io.Reader, _ int64) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		part, err := mw.CreateFormFile("file", key)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url(key), pr)
+	if err != nil {
+		return fmt.Errorf("storage: seaweedfs building request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage: seaweedfs put: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("storage: seaweedfs put returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Get downloads the object stored under key.
+func (s *SeaweedStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage: seaweedfs building request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: seaweedfs get: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage: seaweedfs get: %s not found", key)
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("storage: seaweedfs get returned %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Stat issues a HEAD request against the filer to read object metadata.
+func (s *SeaweedStorage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.url(key), nil)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: seaweedfs building request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("storage: seaweedfs stat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return ObjectInfo{}, fmt.Errorf("storage: seaweedfs stat returned %s", resp.Status)
+	}
+
+	info := ObjectInfo{Key: key, Size: resp.ContentLength}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		if t, err := time.Parse(http.TimeFormat, lm); err == nil {
+			info.ModTime = t
+		}
+	}
+	return info, nil
+}
+
+// Delete removes the object stored under key.
+func (s *SeaweedStorage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.url(key), nil)
+	if err != nil {
+		return fmt.Errorf("storage: seaweedfs building request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage: seaweedfs delete: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("storage: seaweedfs delete returned %s", resp.Status)
+	}
+	return nil
+}
+
+// filerListEntry mirrors the subset of the filer directory-listing response
+// we care about.
+type filerListEntry struct {
+	FullPath string `json:"FullPath"`
+	FileSize int64  `json:"FileSize"`
+	Mtime    string `json:"Mtime"`
+}
+
+type filerListResponse struct {
+	Entries []filerListEntry `json:"Entries"`
+}
+
+// List asks the filer to list the directory named by prefix and returns the
+// entries of the filer's first listing page. prefix is treated as a
+// directory path, not as a partial key, and subdirectories are not
+// descended into.
+func (s *SeaweedStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url(prefix)+"?pretty=y", nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage: seaweedfs building request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: seaweedfs list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("storage: seaweedfs list returned %s", resp.Status)
+	}
+
+	var listResp filerListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return nil, fmt.Errorf("storage: seaweedfs decoding list response: %w", err)
+	}
+
+	objects := make([]ObjectInfo, 0, len(listResp.Entries))
+	for _, entry := range listResp.Entries {
+		key := strings.TrimPrefix(entry.FullPath, "/")
+		if s.bucket != "" {
+			key = strings.TrimPrefix(key, s.bucket+"/")
+		}
+		info := ObjectInfo{Key: key, Size: entry.FileSize}
+		if t, err := time.Parse(time.RFC3339, entry.Mtime); err == nil {
+			info.ModTime = t
+		}
+		objects = append(objects, info)
+	}
+	return objects, nil
+}
@@ -0,0 +1,98 @@
+// Package storage defines the pluggable backend abstraction used by the CDN
+// handler to store and retrieve uploaded files. Implementations exist for
+// the local filesystem, S3-compatible object stores, and SeaweedFS.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ObjectInfo describes a stored object without requiring its contents to be
+// read.
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage is the interface implemented by every storage backend. Keys are
+// slash-separated paths relative to the backend's root (bucket, directory,
+// etc.) and are always passed in already-cleaned form by the caller.
+type Storage interface {
+	// Put streams r to the backend under key. size is the total number of
+	// bytes that will be read from r and may be used by backends that need
+	// to know the content length up front (e.g. S3).
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+
+	// Get opens the object stored under key for reading. The caller is
+	// responsible for closing the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Stat returns metadata about the object stored under key.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// Config configures the storage backend selected for a CDN instance.
+type Config struct {
+	// Type selects the backend: "local" (default), "s3", or "seaweedfs".
+	Type string `json:"type"`
+
+	// Directory is the root directory used by the local backend.
+	Directory string `json:"directory"`
+
+	// Bucket is the bucket (S3) or filer directory (SeaweedFS) objects are
+	// stored under.
+	Bucket string `json:"bucket"`
+
+	// Endpoint is the API endpoint for the s3 and seaweedfs backends.
+	Endpoint string `json:"endpoint"`
+
+	// Region is the S3 region. Ignored by other backends.
+	Region string `json:"region"`
+
+	// AccessKey and SecretKey authenticate against the s3 backend.
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+
+	// UseSSL controls whether the s3/seaweedfs backends talk to Endpoint
+	// over HTTPS.
+	UseSSL bool `json:"use_ssl"`
+
+	// ContentAddressed stores uploads under their SHA-256 digest instead of
+	// their original filename, deduplicating identical uploads. See the
+	// dedup package for the key scheme and alias bookkeeping.
+	ContentAddressed bool `json:"content_addressed"`
+
+	// AliasDB is the path to the bbolt database mapping original filenames
+	// to digest keys when ContentAddressed is enabled. Defaults to
+	// "content-aliases.db".
+	AliasDB string `json:"alias_db"`
+}
+
+// New constructs the Storage backend selected by cfg.Type. An empty Type
+// defaults to the local filesystem backend.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Type {
+	case "", "local":
+		dir := cfg.Directory
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStorage(dir)
+	case "s3":
+		return NewS3Storage(cfg)
+	case "seaweedfs":
+		return NewSeaweedStorage(cfg)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend type %q", cfg.Type)
+	}
+}
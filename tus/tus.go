@@ -0,0 +1,227 @@
+// Package tus implements the server-side storage primitives needed for the
+// tus.io resumable upload protocol (https://tus.io/protocols/resumable-upload).
+// In-progress uploads are kept as `.partial` files with a small JSON sidecar
+// recording their offset, total length, and metadata; Store.Finalize hands
+// back the completed file so the caller can move it into permanent storage.
+package tus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// ProtocolVersion is the tus.io protocol version this package implements.
+const ProtocolVersion = "1.0.0"
+
+// Info describes an in-progress or completed upload.
+type Info struct {
+	ID        string            `json:"id"`
+	Offset    int64             `json:"offset"`
+	Length    int64             `json:"length"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Store manages partial upload state on the local filesystem.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a Store rooted at dir, creating dir if necessary.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("tus: creating upload directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// uploadIDPattern matches the lowercase-hex format newUploadID generates.
+// Every public Store method validates its id argument against it before
+// building a filesystem path, since id otherwise comes straight from a
+// client-supplied URL path (see handleTusPatch/handleTusHead) and could
+// otherwise be used to escape s.dir the same way a crafted storage key
+// could escape LocalStorage's root.
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func (s *Store) partialPath(id string) (string, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", fmt.Errorf("tus: invalid upload id %q", id)
+	}
+	return filepath.Join(s.dir, id+".partial"), nil
+}
+
+func (s *Store) sidecarPath(id string) (string, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", fmt.Errorf("tus: invalid upload id %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("tus: generating upload id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Create reserves a new upload of the given total length and metadata,
+// returning its ID.
+func (s *Store) Create(length int64, metadata map[string]string) (Info, error) {
+	id, err := newUploadID()
+	if err != nil {
+		return Info{}, err
+	}
+
+	partialPath, err := s.partialPath(id)
+	if err != nil {
+		return Info{}, err
+	}
+
+	f, err := os.Create(partialPath)
+	if err != nil {
+		return Info{}, fmt.Errorf("tus: creating partial file: %w", err)
+	}
+	defer f.Close()
+
+	info := Info{ID: id, Offset: 0, Length: length, Metadata: metadata, CreatedAt: time.Now()}
+	if err := s.writeSidecar(info); err != nil {
+		return Info{}, err
+	}
+	return info, nil
+}
+
+func (s *Store) writeSidecar(info Info) error {
+	sidecarPath, err := s.sidecarPath(info.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("tus: marshaling upload metadata: %w", err)
+	}
+	if err := os.WriteFile(sidecarPath, data, 0o600); err != nil {
+		return fmt.Errorf("tus: writing upload metadata: %w", err)
+	}
+	return nil
+}
+
+// Info returns the current state of the upload identified by id.
+func (s *Store) Info(id string) (Info, error) {
+	sidecarPath, err := s.sidecarPath(id)
+	if err != nil {
+		return Info{}, err
+	}
+
+	data, err := os.ReadFile(sidecarPath)
+	if err != nil {
+		return Info{}, fmt.Errorf("tus: reading upload metadata: %w", err)
+	}
+
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, fmt.Errorf("tus: decoding upload metadata: %w", err)
+	}
+	return info, nil
+}
+
+// WriteChunk appends r to the partial file for id starting at offset,
+// failing if offset does not match the upload's current offset, and
+// returns the new offset.
+func (s *Store) WriteChunk(id string, offset int64, r io.Reader) (int64, error) {
+	info, err := s.Info(id)
+	if err != nil {
+		return 0, err
+	}
+	if offset != info.Offset {
+		return 0, fmt.Errorf("tus: offset mismatch: upload %s is at %d, got %d", id, info.Offset, offset)
+	}
+
+	partialPath, err := s.partialPath(id)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(partialPath, os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("tus: opening partial file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("tus: seeking partial file: %w", err)
+	}
+
+	written, err := io.Copy(f, r)
+	if err != nil {
+		return 0, fmt.Errorf("tus: writing chunk: %w", err)
+	}
+
+	info.Offset += written
+	if err := s.writeSidecar(info); err != nil {
+		return 0, err
+	}
+	return info.Offset, nil
+}
+
+// Complete reports whether the upload identified by id has received its
+// full length.
+func (s *Store) Complete(id string) (bool, error) {
+	info, err := s.Info(id)
+	if err != nil {
+		return false, err
+	}
+	return info.Offset >= info.Length, nil
+}
+
+// Finalize opens the completed partial file for id. The caller is
+// responsible for streaming the returned reader into permanent storage and
+// for closing it; the underlying .partial file and its sidecar are removed
+// once the reader is closed.
+func (s *Store) Finalize(id string) (io.ReadCloser, int64, error) {
+	info, err := s.Info(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	partialPath, err := s.partialPath(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, err := os.Open(partialPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("tus: opening completed upload: %w", err)
+	}
+
+	return &finalizeReader{File: f, store: s, id: id}, info.Length, nil
+}
+
+// finalizeReader deletes the partial file and its sidecar once closed, so
+// callers don't need to know the Store's on-disk layout.
+type finalizeReader struct {
+	*os.File
+	store *Store
+	id    string
+}
+
+func (r *finalizeReader) Close() error {
+	err := r.File.Close()
+	// r.id was already validated when this reader was created, so these
+	// can't fail on the id format check.
+	if partialPath, pathErr := r.store.partialPath(r.id); pathErr == nil {
+		os.Remove(partialPath)
+	}
+	if sidecarPath, pathErr := r.store.sidecarPath(r.id); pathErr == nil {
+		os.Remove(sidecarPath)
+	}
+	return err
+}
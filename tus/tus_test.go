@@ -0,0 +1,100 @@
+package tus
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteChunkRejectsOffsetMismatch(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	info, err := s.Create(5, map[string]string{"filename": "foo.txt"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := s.WriteChunk(info.ID, 2, strings.NewReader("xy")); err == nil {
+		t.Fatal("expected WriteChunk to reject a chunk starting at the wrong offset")
+	}
+}
+
+func TestStoreRejectsMalformedUploadID(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	for _, id := range []string{"../../etc/passwd", "not-hex-at-all-not-hex-at-all!!", "", "short"} {
+		if _, err := s.Info(id); err == nil {
+			t.Errorf("Info(%q): expected error for malformed id", id)
+		}
+		if _, err := s.WriteChunk(id, 0, strings.NewReader("x")); err == nil {
+			t.Errorf("WriteChunk(%q): expected error for malformed id", id)
+		}
+		if _, _, err := s.Finalize(id); err == nil {
+			t.Errorf("Finalize(%q): expected error for malformed id", id)
+		}
+	}
+}
+
+func TestWriteChunkCompleteAndFinalize(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	info, err := s.Create(5, map[string]string{"filename": "foo.txt"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	offset, err := s.WriteChunk(info.ID, 0, strings.NewReader("hel"))
+	if err != nil {
+		t.Fatalf("WriteChunk (first): %v", err)
+	}
+	if offset != 3 {
+		t.Fatalf("offset after first chunk = %d, want 3", offset)
+	}
+
+	if complete, err := s.Complete(info.ID); err != nil || complete {
+		t.Fatalf("Complete after first chunk = %v, %v, want false, nil", complete, err)
+	}
+
+	offset, err = s.WriteChunk(info.ID, 3, strings.NewReader("lo"))
+	if err != nil {
+		t.Fatalf("WriteChunk (second): %v", err)
+	}
+	if offset != 5 {
+		t.Fatalf("offset after second chunk = %d, want 5", offset)
+	}
+
+	complete, err := s.Complete(info.ID)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if !complete {
+		t.Fatal("expected upload to be complete once offset reaches length")
+	}
+
+	r, size, err := s.Finalize(info.ID)
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	defer r.Close()
+
+	if size != 5 {
+		t.Fatalf("Finalize size = %d, want 5", size)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading finalized upload: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("finalized content = %q, want %q", data, "hello")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/NotAShelf/go-cdn/auth"
+	"github.com/NotAShelf/go-cdn/tus"
+)
+
+// tusExtensions lists the tus.io protocol extensions this server supports.
+const tusExtensions = "creation"
+
+// handleTusOptions responds to the tus.io discovery preflight request.
+func (c *CDNHandler) handleTusOptions(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Tus-Resumable", tus.ProtocolVersion)
+	w.Header().Set("Tus-Version", tus.ProtocolVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(c.Config.MaxUploadSize, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTusCreate handles a tus.io "POST" upload creation request.
+func (c *CDNHandler) handleTusCreate(w http.ResponseWriter, r *http.Request) {
+	c.Logger.Info("Received tus upload creation request")
+
+	if c.Auth != nil && !c.Auth.Authorize(r, auth.ScopeUpload) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	if c.Config.MaxUploadSize > 0 && length > c.Config.MaxUploadSize {
+		http.Error(w, "Payload Too Large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	info, err := c.Tus.Create(length, parseUploadMetadata(r.Header.Get("Upload-Metadata")))
+	if err != nil {
+		c.Logger.Errorf("Error creating tus upload: %v", err)
+		http.Error(w, "Error creating upload", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tus.ProtocolVersion)
+	w.Header().Set("Location", "/"+info.ID)
+	w.Header().Set("Upload-Offset", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleTusPatch handles a tus.io "PATCH" upload chunk request, finalizing
+// the upload into the configured storage backend once it is complete.
+func (c *CDNHandler) handleTusPatch(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/")
+	c.Logger.Infof("Received tus PATCH for upload: %s", id)
+
+	if c.Auth != nil && !c.Auth.Authorize(r, auth.ScopeUpload) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	info, err := c.Tus.Info(id)
+	if err != nil {
+		http.Error(w, "Upload Not Found", http.StatusNotFound)
+		return
+	}
+
+	// Cap the chunk at whatever's left of the declared upload length, the
+	// same way handlePost caps a multipart upload against MaxUploadSize, so
+	// a client can't keep PATCHing bytes past Upload-Length indefinitely.
+	remaining := info.Length - offset
+	if remaining < 0 {
+		remaining = 0
+	}
+	if c.Config.MaxUploadSize > 0 && c.Config.MaxUploadSize < remaining {
+		remaining = c.Config.MaxUploadSize
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, remaining)
+
+	newOffset, err := c.Tus.WriteChunk(id, offset, r.Body)
+	if err != nil {
+		c.Logger.Errorf("Error writing tus chunk: %v", err)
+		http.Error(w, "Error writing upload", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tus.ProtocolVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+
+	complete, err := c.Tus.Complete(id)
+	if err != nil {
+		c.Logger.Errorf("Error checking tus upload completion: %v", err)
+		http.Error(w, "Error finalizing upload", http.StatusInternalServerError)
+		return
+	}
+	if !complete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rejected, err := c.finalizeTusUpload(w, r, id)
+	if err != nil {
+		c.Logger.Errorf("Error finalizing tus upload: %v", err)
+		http.Error(w, "Error finalizing upload", http.StatusInternalServerError)
+		return
+	}
+	if rejected {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finalizeTusUpload streams the completed partial upload into the
+// configured storage backend under its original filename (falling back to
+// its upload ID), running it through the same scanning, metrics, and
+// content-addressing steps as a multipart upload before recording lifecycle
+// metadata. It returns true if the upload was rejected by the scanner (a
+// response has already been written).
+func (c *CDNHandler) finalizeTusUpload(w http.ResponseWriter, r *http.Request, id string) (bool, error) {
+	info, err := c.Tus.Info(id)
+	if err != nil {
+		return false, err
+	}
+
+	reader, size, err := c.Tus.Finalize(id)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	filename := info.Metadata["filename"]
+	if filename == "" {
+		filename = id
+	}
+
+	storageKey := filename
+	skipScan := false
+	if c.Dedup != nil {
+		key, duplicate, err := c.storeContentAddressed(r.Context(), reader, filename)
+		if err != nil {
+			return false, err
+		}
+		storageKey = key
+		skipScan = duplicate
+	} else if err := c.Storage.Put(r.Context(), filename, reader, size); err != nil {
+		return false, err
+	}
+
+	if c.Metrics != nil {
+		c.Metrics.BytesUploaded.Add(float64(size))
+	}
+
+	if !skipScan {
+		rejected, err := c.scanUpload(r.Context(), w, storageKey)
+		if err != nil {
+			return false, err
+		}
+		if rejected {
+			if c.Dedup != nil {
+				if err := c.Dedup.Delete(filename); err != nil {
+					c.Logger.Errorf("Error removing alias for rejected upload %s: %v", filename, err)
+				}
+			}
+			return true, nil
+		}
+	}
+
+	if c.Lifecycle != nil {
+		if err := c.Lifecycle.Record(storageKey, size, 0); err != nil {
+			c.Logger.Errorf("Error recording upload metadata: %v", err)
+		}
+	}
+
+	c.Logger.Infof("Tus upload completed: %s", filename)
+	return false, nil
+}
+
+// handleTusHead handles a tus.io "HEAD" offset query request.
+func (c *CDNHandler) handleTusHead(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/")
+
+	info, err := c.Tus.Info(id)
+	if err != nil {
+		http.Error(w, "Upload Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tus.ProtocolVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseUploadMetadata decodes a tus.io Upload-Metadata header, a
+// comma-separated list of "key base64(value)" pairs.
+func parseUploadMetadata(header string) map[string]string {
+	if header == "" {
+		return nil
+	}
+
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		metadata[parts[0]] = string(value)
+	}
+	return metadata
+}